@@ -13,6 +13,7 @@ import (
 	"github.com/urfave/cli"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 )
 
 const (
@@ -34,6 +35,14 @@ func Poplar() cli.Command {
 }
 
 func poplarGRPC() cli.Command {
+	const (
+		certFileFlagName  = "certfile"
+		keyFileFlagName   = "keyfile"
+		caFileFlagName    = "cafile"
+		authTokenFlagName = "auth-token"
+		authFileFlagName  = "auth-token-file"
+	)
+
 	return cli.Command{
 		Name:  "grpc",
 		Usage: "run an RPC service for accumulating raw event payloads",
@@ -48,6 +57,26 @@ func poplarGRPC() cli.Command {
 				EnvVar: envVarJasperRPCHost,
 				Value:  envVarPoplarRecorderRPCHost,
 			},
+			cli.StringFlag{
+				Name:  certFileFlagName,
+				Usage: "specify the server certificate to enable TLS",
+			},
+			cli.StringFlag{
+				Name:  keyFileFlagName,
+				Usage: "specify the server key to enable TLS",
+			},
+			cli.StringFlag{
+				Name:  caFileFlagName,
+				Usage: "specify a client CA bundle to require and verify client certificates (mTLS)",
+			},
+			cli.StringFlag{
+				Name:  authTokenFlagName,
+				Usage: "require incoming requests to present this token",
+			},
+			cli.StringFlag{
+				Name:  authFileFlagName,
+				Usage: "require incoming requests to present a token listed in this file",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			port := c.Int("port")
@@ -61,8 +90,39 @@ func poplarGRPC() cli.Command {
 				return errors.WithStack(err)
 			}
 
+			var opts []grpc.ServerOption
+
+			certFile := c.String(certFileFlagName)
+			keyFile := c.String(keyFileFlagName)
+			caFile := c.String(caFileFlagName)
+			if caFile != "" && certFile == "" && keyFile == "" {
+				return errors.Errorf("'%s' requires '%s' and '%s' to also be set", caFileFlagName, certFileFlagName, keyFileFlagName)
+			}
+			if certFile != "" || keyFile != "" {
+				tlsConf, err := loadServerTLSConfig(certFile, keyFile, caFile)
+				if err != nil {
+					return errors.Wrap(err, "configuring TLS")
+				}
+				opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+			}
+
+			var auth Authenticator
+			switch {
+			case c.String(authTokenFlagName) != "":
+				auth = NewStaticTokenAuthenticator(c.String(authTokenFlagName))
+			case c.String(authFileFlagName) != "":
+				auth, err = NewFileTokenAuthenticator(c.String(authFileFlagName))
+				if err != nil {
+					return errors.Wrap(err, "configuring auth")
+				}
+			}
+
+			if auth != nil {
+				opts = append(opts, grpc.UnaryInterceptor(unaryAuthInterceptor(auth)), grpc.StreamInterceptor(streamAuthInterceptor(auth)))
+			}
+
 			grip.Infof("starting poplar gRPC service at '%s'", addr)
-			rpcSrv := grpc.NewServer()
+			rpcSrv := grpc.NewServer(opts...)
 
 			registry := poplar.NewRegistry()
 			if err = rpc.AttachService(registry, rpcSrv); err != nil {
@@ -90,10 +150,14 @@ func poplarGRPC() cli.Command {
 
 func poplarReport() cli.Command {
 	const (
-		serviceFlagName  = "service"
-		pathFlagName     = "path"
-		insecureFlagName = "insecure"
-		certFileFlagName = "certfile"
+		serviceFlagName        = "service"
+		pathFlagName           = "path"
+		insecureFlagName       = "insecure"
+		certFileFlagName       = "certfile"
+		caFileFlagName         = "cafile"
+		clientCertFileFlagName = "client-certfile"
+		clientKeyFileFlagName  = "client-keyfile"
+		authTokenFlagName      = "auth-token"
 	)
 
 	return cli.Command{
@@ -110,39 +174,98 @@ func poplarReport() cli.Command {
 			},
 			cli.StringFlag{
 				Name:  certFileFlagName,
-				Usage: "specify the client certificate to connect over TLS",
+				Usage: "specify a CA bundle to validate the server certificate (deprecated alias for '" + caFileFlagName + "')",
+			},
+			cli.StringFlag{
+				Name:  caFileFlagName,
+				Usage: "specify a CA bundle to validate the server certificate",
+			},
+			cli.StringFlag{
+				Name:  clientCertFileFlagName,
+				Usage: "specify the client certificate to connect over mTLS",
+			},
+			cli.StringFlag{
+				Name:  clientKeyFileFlagName,
+				Usage: "specify the client key to connect over mTLS",
+			},
+			cli.StringFlag{
+				Name:  authTokenFlagName,
+				Usage: "attach this token as credentials for the poplar recorder",
 			},
 			cli.StringFlag{
 				Name:  pathFlagName,
-				Usage: "specify the path of the input file, may be the first positional argument",
+				Usage: "specify the path of the input file, may be the first positional argument; use '-', or omit it while stdin is not a terminal, to read from stdin",
 			},
 		},
-		Before: mergeBeforeFuncs(
-			requireStringFlag(serviceFlagName),
-			requireFileOrPositional(pathFlagName),
-		),
+		Before: func(c *cli.Context) error {
+			if err := requireStringFlag(serviceFlagName)(c); err != nil {
+				return err
+			}
+
+			if shouldReadReportFromStdin(resolvedReportPath(c, pathFlagName)) {
+				return nil
+			}
+
+			return requireFileOrPositional(pathFlagName)(c)
+		},
 		Action: func(c *cli.Context) error {
 			addr := c.String(serviceFlagName)
-			fileName := c.String(pathFlagName)
+			fileName := resolvedReportPath(c, pathFlagName)
 			isInsecure := c.Bool(insecureFlagName)
 			certFile := c.String(certFileFlagName)
+			caFile := c.String(caFileFlagName)
+			clientCertFile := c.String(clientCertFileFlagName)
+			clientKeyFile := c.String(clientKeyFileFlagName)
+			authToken := c.String(authTokenFlagName)
 
-			report, err := poplar.LoadReport(fileName)
-			if err != nil {
-				return errors.WithStack(err)
+			// certfile is a deprecated alias for cafile, kept so
+			// scripts that still pass "--certfile=ca.pem" to pin
+			// the server's CA (its pre-mTLS meaning) keep working.
+			if caFile == "" {
+				caFile = certFile
+			}
+
+			var report *poplar.Report
+			if shouldReadReportFromStdin(fileName) {
+				loaded, err := loadReportFromStdin()
+				if err != nil {
+					return errors.Wrap(err, "reading report from stdin")
+				}
+				report = loaded
+			} else {
+				loaded, err := poplar.LoadReport(fileName)
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				report = loaded
+			}
+
+			if (clientCertFile != "") != (clientKeyFile != "") {
+				return errors.Errorf("'%s' and '%s' must be set together for client certificate authentication", clientCertFileFlagName, clientKeyFileFlagName)
 			}
 
 			rpcOpts := []grpc.DialOption{}
 			if isInsecure {
 				rpcOpts = append(rpcOpts, grpc.WithInsecure())
 			} else {
-				creds, _ := credentials.NewClientTLSFromFile(certFile, "")
-				rpcOpts = append(rpcOpts, grpc.WithTransportCredentials(creds))
+				tlsConf, err := loadClientTLSConfig(clientCertFile, clientKeyFile, caFile)
+				if err != nil {
+					return errors.Wrap(err, "configuring TLS")
+				}
+				rpcOpts = append(rpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConf)))
 			}
 
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 
+			if authToken != "" {
+				encoded, err := encodeAuthCredentials(authToken)
+				if err != nil {
+					return errors.Wrap(err, "encoding auth credentials")
+				}
+				ctx = metadata.AppendToOutgoingContext(ctx, poplarAuthMetadataKey, encoded)
+			}
+
 			conn, err := grpc.DialContext(ctx, addr, rpcOpts...)
 			if err != nil {
 				return errors.WithStack(err)
@@ -0,0 +1,245 @@
+package operations
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// poplarAuthMetadataKey is the gRPC metadata key that carries the
+// base64-encoded auth credentials, analogous to Docker's
+// "X-Registry-Auth" header.
+const poplarAuthMetadataKey = "x-poplar-auth"
+
+// poplarAuthCredentials is the JSON payload that gets base64-encoded
+// and sent as gRPC metadata under poplarAuthMetadataKey.
+type poplarAuthCredentials struct {
+	Token string `json:"token"`
+}
+
+// Authenticator validates the token carried in a poplar gRPC request.
+// Implementations may check the token against a static value, a file
+// of known tokens, or any user-supplied callback.
+type Authenticator interface {
+	Authenticate(token string) error
+}
+
+// AuthenticatorFunc adapts a plain function to the Authenticator
+// interface, so callers can supply an arbitrary callback.
+type AuthenticatorFunc func(token string) error
+
+func (f AuthenticatorFunc) Authenticate(token string) error { return f(token) }
+
+// staticTokenAuthenticator accepts a single, fixed token.
+type staticTokenAuthenticator struct {
+	token string
+}
+
+// NewStaticTokenAuthenticator returns an Authenticator that accepts
+// requests carrying exactly the given token.
+func NewStaticTokenAuthenticator(token string) Authenticator {
+	return &staticTokenAuthenticator{token: token}
+}
+
+func (a *staticTokenAuthenticator) Authenticate(token string) error {
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+		return errors.New("invalid or missing auth token")
+	}
+
+	return nil
+}
+
+// fileTokenAuthenticator accepts any token listed, one per line, in a
+// file on disk.
+type fileTokenAuthenticator struct {
+	tokens map[string]struct{}
+}
+
+// NewFileTokenAuthenticator reads newline-separated tokens from path
+// and returns an Authenticator that accepts any of them.
+func NewFileTokenAuthenticator(path string) (Authenticator, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading token file '%s'", path)
+	}
+
+	tokens := map[string]struct{}{}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		tokens[line] = struct{}{}
+	}
+
+	return &fileTokenAuthenticator{tokens: tokens}, nil
+}
+
+func (a *fileTokenAuthenticator) Authenticate(token string) error {
+	matched := 0
+	for known := range a.tokens {
+		matched |= subtle.ConstantTimeCompare([]byte(token), []byte(known))
+	}
+
+	if token == "" || matched != 1 {
+		return errors.New("invalid or missing auth token")
+	}
+
+	return nil
+}
+
+// encodeAuthCredentials base64-encodes a JSON blob carrying token, for
+// attaching to outgoing gRPC metadata.
+func encodeAuthCredentials(token string) (string, error) {
+	data, err := json.Marshal(poplarAuthCredentials{Token: token})
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling auth credentials")
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// decodeAuthCredentials reverses encodeAuthCredentials.
+func decodeAuthCredentials(encoded string) (*poplarAuthCredentials, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding auth credentials")
+	}
+
+	creds := &poplarAuthCredentials{}
+	if err = json.Unmarshal(data, creds); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling auth credentials")
+	}
+
+	return creds, nil
+}
+
+// authTokenFromContext extracts and decodes the auth token carried in
+// incoming gRPC metadata, if any.
+func authTokenFromContext(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", nil
+	}
+
+	vals := md.Get(poplarAuthMetadataKey)
+	if len(vals) == 0 {
+		return "", nil
+	}
+
+	creds, err := decodeAuthCredentials(vals[0])
+	if err != nil {
+		return "", err
+	}
+
+	return creds.Token, nil
+}
+
+// unaryAuthInterceptor builds a grpc.UnaryServerInterceptor that
+// rejects requests which do not carry a token accepted by auth.
+func unaryAuthInterceptor(auth Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		token, err := authTokenFromContext(ctx)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		if err := auth.Authenticate(token); err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// streamAuthInterceptor builds a grpc.StreamServerInterceptor that
+// rejects streams which do not carry a token accepted by auth.
+func streamAuthInterceptor(auth Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		token, err := authTokenFromContext(ss.Context())
+		if err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		if err := auth.Authenticate(token); err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// loadServerTLSConfig builds a *tls.Config for the poplar gRPC
+// service from the given cert/key pair, optionally requiring and
+// verifying client certificates against cafile for mTLS.
+func loadServerTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading server certificate/key pair")
+	}
+
+	conf := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if caFile == "" {
+		return conf, nil
+	}
+
+	caData, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading client CA file '%s'", caFile)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return nil, errors.Errorf("no valid certificates found in '%s'", caFile)
+	}
+
+	conf.ClientCAs = pool
+	conf.ClientAuth = tls.RequireAndVerifyClientCert
+
+	return conf, nil
+}
+
+// loadClientTLSConfig builds a *tls.Config for dialing the poplar
+// gRPC service, trusting cafile (if given) as the server's CA and
+// presenting certfile/keyfile (if given) as a client certificate for
+// mTLS.
+func loadClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	conf := &tls.Config{}
+
+	if caFile != "" {
+		caData, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading CA file '%s'", caFile)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, errors.Errorf("no valid certificates found in '%s'", caFile)
+		}
+
+		conf.RootCAs = pool
+	}
+
+	if certFile != "" && keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading client certificate/key pair")
+		}
+
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	return conf, nil
+}
@@ -0,0 +1,364 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// writeTestKeyPair generates a self-signed certificate/key pair,
+// writes both as PEM to certFile/keyFile, and returns the parsed
+// certificate for use as a CA.
+func writeTestKeyPair(t *testing.T, dir, name string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Unix(0, 0),
+		NotAfter:              time.Unix(0, 0).Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %s", err)
+	}
+
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parsing certificate: %s", err)
+	}
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshalling key: %s", err)
+	}
+
+	certFile = filepath.Join(dir, name+"-cert.pem")
+	keyFile = filepath.Join(dir, name+"-key.pem")
+
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatalf("writing cert file: %s", err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0644); err != nil {
+		t.Fatalf("writing key file: %s", err)
+	}
+
+	return certFile, keyFile, cert
+}
+
+func TestLoadServerTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _ := writeTestKeyPair(t, dir, "server")
+	caFile, _, caCert := writeTestKeyPair(t, dir, "ca")
+
+	t.Run("WithoutCA", func(t *testing.T) {
+		conf, err := loadServerTLSConfig(certFile, keyFile, "")
+		if err != nil {
+			t.Fatalf("loadServerTLSConfig returned error: %s", err)
+		}
+		if len(conf.Certificates) != 1 {
+			t.Fatalf("expected 1 certificate, got %d", len(conf.Certificates))
+		}
+		if conf.ClientAuth != tls.NoClientCert {
+			t.Fatalf("expected no client auth requirement, got %v", conf.ClientAuth)
+		}
+	})
+
+	t.Run("WithCARequiresClientCerts", func(t *testing.T) {
+		conf, err := loadServerTLSConfig(certFile, keyFile, caFile)
+		if err != nil {
+			t.Fatalf("loadServerTLSConfig returned error: %s", err)
+		}
+		if conf.ClientAuth != tls.RequireAndVerifyClientCert {
+			t.Fatalf("expected RequireAndVerifyClientCert, got %v", conf.ClientAuth)
+		}
+		if conf.ClientCAs == nil || !bytes.Equal(conf.ClientCAs.Subjects()[0], caCert.RawSubject) {
+			t.Fatalf("expected client CA pool to contain the CA certificate")
+		}
+	})
+
+	t.Run("MissingCertFile", func(t *testing.T) {
+		if _, err := loadServerTLSConfig(filepath.Join(dir, "missing.pem"), keyFile, ""); err == nil {
+			t.Fatalf("expected an error for a missing certificate file")
+		}
+	})
+
+	t.Run("UnreadableCAFile", func(t *testing.T) {
+		if _, err := loadServerTLSConfig(certFile, keyFile, filepath.Join(dir, "missing-ca.pem")); err == nil {
+			t.Fatalf("expected an error for a missing CA file")
+		}
+	})
+}
+
+func TestLoadClientTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _ := writeTestKeyPair(t, dir, "client")
+	caFile, _, _ := writeTestKeyPair(t, dir, "ca")
+
+	t.Run("Empty", func(t *testing.T) {
+		conf, err := loadClientTLSConfig("", "", "")
+		if err != nil {
+			t.Fatalf("loadClientTLSConfig returned error: %s", err)
+		}
+		if len(conf.Certificates) != 0 || conf.RootCAs != nil {
+			t.Fatalf("expected an empty TLS config")
+		}
+	})
+
+	t.Run("CAOnly", func(t *testing.T) {
+		conf, err := loadClientTLSConfig("", "", caFile)
+		if err != nil {
+			t.Fatalf("loadClientTLSConfig returned error: %s", err)
+		}
+		if conf.RootCAs == nil {
+			t.Fatalf("expected the CA bundle to be loaded into RootCAs")
+		}
+		if len(conf.Certificates) != 0 {
+			t.Fatalf("expected no client certificate without certfile/keyfile")
+		}
+	})
+
+	t.Run("ClientCertPair", func(t *testing.T) {
+		conf, err := loadClientTLSConfig(certFile, keyFile, "")
+		if err != nil {
+			t.Fatalf("loadClientTLSConfig returned error: %s", err)
+		}
+		if len(conf.Certificates) != 1 {
+			t.Fatalf("expected 1 client certificate, got %d", len(conf.Certificates))
+		}
+	})
+
+	t.Run("UnreadableCAFile", func(t *testing.T) {
+		if _, err := loadClientTLSConfig("", "", filepath.Join(dir, "missing-ca.pem")); err == nil {
+			t.Fatalf("expected an error for a missing CA file")
+		}
+	})
+
+	t.Run("BadKeyPair", func(t *testing.T) {
+		if _, err := loadClientTLSConfig(certFile, filepath.Join(dir, "missing-key.pem"), ""); err == nil {
+			t.Fatalf("expected an error for a missing key file")
+		}
+	})
+}
+
+func TestStaticTokenAuthenticator(t *testing.T) {
+	auth := NewStaticTokenAuthenticator("s3cr3t")
+
+	if err := auth.Authenticate("s3cr3t"); err != nil {
+		t.Fatalf("expected the configured token to be accepted, got error: %s", err)
+	}
+	if err := auth.Authenticate("wrong"); err == nil {
+		t.Fatalf("expected a mismatched token to be rejected")
+	}
+	if err := auth.Authenticate(""); err == nil {
+		t.Fatalf("expected an empty token to be rejected")
+	}
+}
+
+func TestFileTokenAuthenticator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.txt")
+	if err := ioutil.WriteFile(path, []byte("one\ntwo\n\nthree\n"), 0644); err != nil {
+		t.Fatalf("writing token file: %s", err)
+	}
+
+	auth, err := NewFileTokenAuthenticator(path)
+	if err != nil {
+		t.Fatalf("NewFileTokenAuthenticator returned error: %s", err)
+	}
+
+	for _, tok := range []string{"one", "two", "three"} {
+		if err := auth.Authenticate(tok); err != nil {
+			t.Fatalf("expected listed token %q to be accepted, got error: %s", tok, err)
+		}
+	}
+	if err := auth.Authenticate("four"); err == nil {
+		t.Fatalf("expected an unlisted token to be rejected")
+	}
+	if err := auth.Authenticate(""); err == nil {
+		t.Fatalf("expected an empty token to be rejected")
+	}
+
+	if _, err := NewFileTokenAuthenticator(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatalf("expected an error for a missing token file")
+	}
+}
+
+func TestEncodeDecodeAuthCredentials(t *testing.T) {
+	encoded, err := encodeAuthCredentials("s3cr3t")
+	if err != nil {
+		t.Fatalf("encodeAuthCredentials returned error: %s", err)
+	}
+
+	creds, err := decodeAuthCredentials(encoded)
+	if err != nil {
+		t.Fatalf("decodeAuthCredentials returned error: %s", err)
+	}
+	if creds.Token != "s3cr3t" {
+		t.Fatalf("expected token 's3cr3t', got %q", creds.Token)
+	}
+
+	if _, err := decodeAuthCredentials("not-base64!"); err == nil {
+		t.Fatalf("expected an error decoding invalid base64")
+	}
+}
+
+// incomingContextWithToken builds a context carrying token the same
+// way a poplar gRPC client attaches it via encodeAuthCredentials.
+func incomingContextWithToken(t *testing.T, token string) context.Context {
+	t.Helper()
+
+	encoded, err := encodeAuthCredentials(token)
+	if err != nil {
+		t.Fatalf("encodeAuthCredentials returned error: %s", err)
+	}
+
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(poplarAuthMetadataKey, encoded))
+}
+
+func assertUnauthenticated(t *testing.T, err error) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected codes.Unauthenticated, got %s", status.Code(err))
+	}
+}
+
+func TestUnaryAuthInterceptor(t *testing.T) {
+	interceptor := unaryAuthInterceptor(NewStaticTokenAuthenticator("good-token"))
+
+	t.Run("ValidToken", func(t *testing.T) {
+		handlerCalled := false
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			handlerCalled = true
+			return "ok", nil
+		}
+
+		resp, err := interceptor(incomingContextWithToken(t, "good-token"), nil, &grpc.UnaryServerInfo{}, handler)
+		if err != nil {
+			t.Fatalf("interceptor returned error: %s", err)
+		}
+		if !handlerCalled {
+			t.Fatalf("expected handler to be called")
+		}
+		if resp != "ok" {
+			t.Fatalf("expected handler response to pass through, got %v", resp)
+		}
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		handlerCalled := false
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			handlerCalled = true
+			return "ok", nil
+		}
+
+		_, err := interceptor(incomingContextWithToken(t, "bad-token"), nil, &grpc.UnaryServerInfo{}, handler)
+		assertUnauthenticated(t, err)
+		if handlerCalled {
+			t.Fatalf("expected handler not to be called")
+		}
+	})
+
+	t.Run("MissingToken", func(t *testing.T) {
+		handlerCalled := false
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			handlerCalled = true
+			return "ok", nil
+		}
+
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+		assertUnauthenticated(t, err)
+		if handlerCalled {
+			t.Fatalf("expected handler not to be called")
+		}
+	})
+}
+
+// fakeServerStream is a minimal grpc.ServerStream that only honors
+// Context(), enough to exercise streamAuthInterceptor.
+type fakeServerStream struct {
+	ctx context.Context
+}
+
+func (s *fakeServerStream) SetHeader(metadata.MD) error  { return nil }
+func (s *fakeServerStream) SendHeader(metadata.MD) error { return nil }
+func (s *fakeServerStream) SetTrailer(metadata.MD)       {}
+func (s *fakeServerStream) Context() context.Context     { return s.ctx }
+func (s *fakeServerStream) SendMsg(m interface{}) error  { return nil }
+func (s *fakeServerStream) RecvMsg(m interface{}) error  { return nil }
+
+func TestStreamAuthInterceptor(t *testing.T) {
+	interceptor := streamAuthInterceptor(NewStaticTokenAuthenticator("good-token"))
+
+	t.Run("ValidToken", func(t *testing.T) {
+		handlerCalled := false
+		handler := func(srv interface{}, ss grpc.ServerStream) error {
+			handlerCalled = true
+			return nil
+		}
+
+		ss := &fakeServerStream{ctx: incomingContextWithToken(t, "good-token")}
+		if err := interceptor(nil, ss, &grpc.StreamServerInfo{}, handler); err != nil {
+			t.Fatalf("interceptor returned error: %s", err)
+		}
+		if !handlerCalled {
+			t.Fatalf("expected handler to be called")
+		}
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		handlerCalled := false
+		handler := func(srv interface{}, ss grpc.ServerStream) error {
+			handlerCalled = true
+			return nil
+		}
+
+		ss := &fakeServerStream{ctx: incomingContextWithToken(t, "bad-token")}
+		assertUnauthenticated(t, interceptor(nil, ss, &grpc.StreamServerInfo{}, handler))
+		if handlerCalled {
+			t.Fatalf("expected handler not to be called")
+		}
+	})
+
+	t.Run("MissingToken", func(t *testing.T) {
+		handlerCalled := false
+		handler := func(srv interface{}, ss grpc.ServerStream) error {
+			handlerCalled = true
+			return nil
+		}
+
+		ss := &fakeServerStream{ctx: context.Background()}
+		assertUnauthenticated(t, interceptor(nil, ss, &grpc.StreamServerInfo{}, handler))
+		if handlerCalled {
+			t.Fatalf("expected handler not to be called")
+		}
+	})
+}
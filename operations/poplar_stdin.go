@@ -0,0 +1,89 @@
+package operations
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/evergreen-ci/poplar"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+// resolvedReportPath returns the effective report path for the send
+// command, preferring the --path flag but falling back to the first
+// positional argument.
+func resolvedReportPath(c *cli.Context, pathFlagName string) string {
+	if path := c.String(pathFlagName); path != "" {
+		return path
+	}
+
+	if c.Args().Present() {
+		return c.Args().First()
+	}
+
+	return ""
+}
+
+// shouldReadReportFromStdin reports whether the send command should
+// read the report from os.Stdin rather than a file on disk: the path
+// is explicitly "-", or it was omitted and stdin is not a terminal.
+func shouldReadReportFromStdin(path string) bool {
+	if path == "-" {
+		return true
+	}
+
+	if path != "" {
+		return false
+	}
+
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
+// materializeStdinReport returns an io.Reader over the report piped
+// into os.Stdin, along with its length. A regular file redirected
+// into stdin is already seekable, so it's returned as-is; a named
+// pipe or other non-seekable input is buffered into memory so the
+// caller has a known byte count, mirroring the materializeFile
+// pattern used elsewhere for non-seekable inputs.
+func materializeStdinReport() (io.Reader, int64, error) {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "statting stdin")
+	}
+
+	if info.Mode()&(os.ModeCharDevice|os.ModeNamedPipe) == 0 {
+		return os.Stdin, info.Size(), nil
+	}
+
+	data, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "buffering report from stdin")
+	}
+
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// loadReportFromStdin reads a report piped into stdin and decodes it
+// directly, since poplar only exposes a path-based loader and there's
+// no upstream reader-based equivalent to call.
+func loadReportFromStdin() (*poplar.Report, error) {
+	reader, _, err := materializeStdinReport()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	report := &poplar.Report{}
+	if err := json.NewDecoder(reader).Decode(report); err != nil {
+		return nil, errors.Wrap(err, "decoding report from stdin")
+	}
+
+	return report, nil
+}
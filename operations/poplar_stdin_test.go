@@ -0,0 +1,174 @@
+package operations
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withStdin temporarily replaces os.Stdin for the duration of fn,
+// restoring it afterward.
+func withStdin(t *testing.T, f *os.File, fn func()) {
+	t.Helper()
+
+	old := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = old }()
+
+	fn()
+}
+
+func TestShouldReadReportFromStdin(t *testing.T) {
+	t.Run("ExplicitDash", func(t *testing.T) {
+		if !shouldReadReportFromStdin("-") {
+			t.Fatalf("expected '-' to read from stdin")
+		}
+	})
+
+	t.Run("ExplicitPath", func(t *testing.T) {
+		if shouldReadReportFromStdin("report.json") {
+			t.Fatalf("expected an explicit path not to read from stdin")
+		}
+	})
+
+	t.Run("EmptyWithPipedStdin", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("creating pipe: %s", err)
+		}
+		defer r.Close()
+		w.Close()
+
+		withStdin(t, r, func() {
+			if !shouldReadReportFromStdin("") {
+				t.Fatalf("expected an empty path with piped stdin to read from stdin")
+			}
+		})
+	})
+
+	t.Run("EmptyWithCharDeviceStdin", func(t *testing.T) {
+		devNull, err := os.Open(os.DevNull)
+		if err != nil {
+			t.Fatalf("opening %s: %s", os.DevNull, err)
+		}
+		defer devNull.Close()
+
+		withStdin(t, devNull, func() {
+			if shouldReadReportFromStdin("") {
+				t.Fatalf("expected an empty path with a character-device stdin not to read from stdin")
+			}
+		})
+	})
+}
+
+func TestMaterializeStdinReportSeekableFile(t *testing.T) {
+	content := "report content"
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("writing fixture file: %s", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening fixture file: %s", err)
+	}
+	defer f.Close()
+
+	withStdin(t, f, func() {
+		reader, size, err := materializeStdinReport()
+		if err != nil {
+			t.Fatalf("materializeStdinReport returned error: %s", err)
+		}
+		if size != int64(len(content)) {
+			t.Fatalf("expected size %d, got %d", len(content), size)
+		}
+
+		got, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("reading materialized report: %s", err)
+		}
+		if string(got) != content {
+			t.Fatalf("expected content %q, got %q", content, string(got))
+		}
+	})
+}
+
+func TestLoadReportFromStdin(t *testing.T) {
+	t.Run("ValidJSON", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("creating pipe: %s", err)
+		}
+		defer r.Close()
+
+		go func() {
+			_, _ = io.WriteString(w, `{}`)
+			w.Close()
+		}()
+
+		withStdin(t, r, func() {
+			report, err := loadReportFromStdin()
+			if err != nil {
+				t.Fatalf("loadReportFromStdin returned error: %s", err)
+			}
+			if report == nil {
+				t.Fatalf("expected a non-nil report")
+			}
+		})
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("creating pipe: %s", err)
+		}
+		defer r.Close()
+
+		go func() {
+			_, _ = io.WriteString(w, "not json")
+			w.Close()
+		}()
+
+		withStdin(t, r, func() {
+			if _, err := loadReportFromStdin(); err == nil {
+				t.Fatalf("expected an error decoding invalid JSON")
+			}
+		})
+	})
+}
+
+func TestMaterializeStdinReportBuffersPipe(t *testing.T) {
+	content := "report content"
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("creating pipe: %s", err)
+	}
+	defer r.Close()
+
+	go func() {
+		_, _ = io.WriteString(w, content)
+		w.Close()
+	}()
+
+	withStdin(t, r, func() {
+		reader, size, err := materializeStdinReport()
+		if err != nil {
+			t.Fatalf("materializeStdinReport returned error: %s", err)
+		}
+		if size != int64(len(content)) {
+			t.Fatalf("expected size %d, got %d", len(content), size)
+		}
+
+		got, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("reading materialized report: %s", err)
+		}
+		if string(got) != content {
+			t.Fatalf("expected content %q, got %q", content, string(got))
+		}
+	})
+}
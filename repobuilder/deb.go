@@ -148,22 +148,23 @@ func (j *BuildDEBRepoJob) rebuildRepo(workingDir string, wg *sync.WaitGroup) {
 
 	arch := "binary-" + j.Arch
 
-	// start by running dpkg-scanpackages to generate a packages file
-	// in the source.
-	dirParts := strings.Split(workingDir, string(filepath.Separator))
-	cmd := exec.Command("dpkg-scanpackages", "--multiversion", filepath.Join(filepath.Join(dirParts[len(dirParts)-5:]...), arch))
-	cmd.Dir = string(filepath.Separator) + filepath.Join(dirParts[:len(dirParts)-5]...)
-
-	grip.Infof("running command='%s' path='%s'", strings.Join(cmd.Args, " "), cmd.Dir)
-	out, err := cmd.Output()
+	// Generate the packages file by shelling out to dpkg-scanpackages,
+	// unless the native generator has been opted into.
+	var pkgsContent []byte
+	var err error
+	if j.Conf.UseNativeTools {
+		pkgsContent, err = buildPackagesFile(filepath.Join(workingDir, arch), filepath.Join(repoRootRelativePath(workingDir), arch))
+	} else {
+		pkgsContent, err = j.scanPackagesExternal(workingDir, arch)
+	}
 	if err != nil {
-		j.addError(errors.Wrapf(err, "building 'Packages': [%s]", string(out)))
+		j.addError(errors.Wrap(err, "building 'Packages'"))
 		return
 	}
 
 	// Write the packages file to disk.
 	pkgsFile := filepath.Join(workingDir, arch, "Packages")
-	err = ioutil.WriteFile(pkgsFile, out, 0644)
+	err = ioutil.WriteFile(pkgsFile, pkgsContent, 0644)
 	if err != nil {
 		j.addError(err)
 		return
@@ -171,7 +172,7 @@ func (j *BuildDEBRepoJob) rebuildRepo(workingDir string, wg *sync.WaitGroup) {
 	grip.Noticeln("wrote packages file to:", pkgsFile)
 
 	// Compress/gzip the packages file
-	err = gzipAndWriteToFile(pkgsFile+".gz", out)
+	err = gzipAndWriteToFile(pkgsFile+".gz", pkgsContent)
 	if err != nil {
 		j.addError(errors.Wrap(err, "compressing the 'Packages' file"))
 		return
@@ -207,27 +208,28 @@ func (j *BuildDEBRepoJob) rebuildRepo(workingDir string, wg *sync.WaitGroup) {
 		return
 	}
 
-	// This builds a Release file using the header info generated
-	// from the template above.
-	cmd = exec.Command("apt-ftparchive", "release", "../")
-	cmd.Dir = workingDir
-	out, err = cmd.Output()
-	grip.Infof("generating release file: [command='%s', path='%s']", strings.Join(cmd.Args, " "), cmd.Dir)
-	outString := string(out)
-	grip.Debug(outString)
+	// Build the checksum blocks that make up the rest of the Release
+	// file by shelling out to apt-ftparchive, unless the native
+	// generator has been opted into.
+	var checksums string
+	if j.Conf.UseNativeTools {
+		checksums, err = j.releaseChecksumsNative(workingDir)
+	} else {
+		checksums, err = j.releaseChecksumsExternal(workingDir)
+	}
 	if err != nil {
 		j.addError(errors.Wrapf(err, "generating Release content for %s", workingDir))
 		return
 	}
 
-	// get the content from the template and add the output of
-	// apt-ftparchive there.
+	// get the content from the template and add the checksum blocks
+	// there.
 	releaseContent := buffer.Bytes()
-	releaseContent = append(releaseContent, out...)
+	releaseContent = append(releaseContent, []byte(checksums)...)
 
 	// tracking the output is useful. we'll do that here.
 	j.mutex.Lock()
-	j.Output["sign-release-file-"+workingDir] = outString
+	j.Output["sign-release-file-"+workingDir] = checksums
 	j.mutex.Unlock()
 
 	// write the content of the release file to disk.
@@ -255,4 +257,76 @@ func (j *BuildDEBRepoJob) rebuildRepo(workingDir string, wg *sync.WaitGroup) {
 		j.addError(errors.Wrapf(err, "building index.html pages for %s", workingDir))
 		return
 	}
+
+	// snapshot the built repo into a single artifact, if configured.
+	if err = j.snapshotRepo(workingDir); err != nil {
+		j.addError(errors.Wrapf(err, "snapshotting repo for %s", workingDir))
+		return
+	}
+}
+
+// repoRootRelativePath returns workingDir's path relative to the real
+// repo root (version/distro/edition/etc. above the component
+// directory), the root that published Filename: entries must resolve
+// against. It keeps the last 5 path segments of workingDir, the same
+// relative path scanPackagesExternal passes to dpkg-scanpackages,
+// which it runs from that root.
+func repoRootRelativePath(workingDir string) string {
+	dirParts := strings.Split(workingDir, string(filepath.Separator))
+	if len(dirParts) < 5 {
+		return workingDir
+	}
+
+	return filepath.Join(dirParts[len(dirParts)-5:]...)
+}
+
+// scanPackagesExternal generates a "Packages" file by shelling out to
+// dpkg-scanpackages, as rebuildRepo did before it grew a native
+// generator. Used unless RepositoryConfig.UseNativeTools is set; no
+// command-line flag selects it.
+func (j *BuildDEBRepoJob) scanPackagesExternal(workingDir, arch string) ([]byte, error) {
+	dirParts := strings.Split(workingDir, string(filepath.Separator))
+	cmd := exec.Command("dpkg-scanpackages", "--multiversion", filepath.Join(repoRootRelativePath(workingDir), arch))
+	cmd.Dir = string(filepath.Separator) + filepath.Join(dirParts[:len(dirParts)-5]...)
+
+	grip.Infof("running command='%s' path='%s'", strings.Join(cmd.Args, " "), cmd.Dir)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Errorf("[%s]: %s", string(out), err.Error())
+	}
+
+	return out, nil
+}
+
+// releaseChecksumsExternal generates the MD5Sum/SHA1/SHA256 blocks of
+// a Release file by shelling out to apt-ftparchive. Used unless
+// RepositoryConfig.UseNativeTools is set; no command-line flag selects
+// it.
+func (j *BuildDEBRepoJob) releaseChecksumsExternal(workingDir string) (string, error) {
+	cmd := exec.Command("apt-ftparchive", "release", "../")
+	cmd.Dir = workingDir
+	out, err := cmd.Output()
+	grip.Infof("generating release file: [command='%s', path='%s']", strings.Join(cmd.Args, " "), cmd.Dir)
+	grip.Debug(string(out))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return string(out), nil
+}
+
+// releaseChecksumsNative generates the MD5Sum/SHA1/SHA256 blocks of a
+// Release file natively, by hashing every Packages* file under the
+// parent of workingDir (the component directory that holds the
+// binary-<arch> directories), the same tree apt-ftparchive's
+// "release ../" covers when run from workingDir.
+func (j *BuildDEBRepoJob) releaseChecksumsNative(workingDir string) (string, error) {
+	componentDir := filepath.Dir(workingDir)
+
+	relFiles, err := packagesFilesIn(componentDir)
+	if err != nil {
+		return "", err
+	}
+
+	return buildReleaseChecksumBlocks(componentDir, relFiles)
 }
@@ -0,0 +1,282 @@
+package repobuilder
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// arGlobalHeader is the magic that begins every "ar" archive, which is
+// the container format .deb packages use.
+const arGlobalHeader = "!<arch>\n"
+
+// buildPackagesFile walks archDir for .deb packages and returns the
+// contents of a "Packages" file: each package's control stanza with
+// Filename, Size, MD5sum, SHA1, and SHA256 fields appended, the way
+// dpkg-scanpackages would produce it, without shelling out.
+func buildPackagesFile(archDir, relBinaryPath string) ([]byte, error) {
+	var names []string
+	if err := filepath.Walk(archDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".deb") {
+			names = append(names, path)
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "walking '%s'", archDir)
+	}
+
+	sort.Strings(names)
+
+	var out bytes.Buffer
+	for _, path := range names {
+		stanza, err := buildPackageStanza(path, relBinaryPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "processing '%s'", path)
+		}
+
+		out.Write(stanza)
+		out.WriteString("\n")
+	}
+
+	return out.Bytes(), nil
+}
+
+// buildPackageStanza extracts the control stanza from the .deb at
+// path and appends the fields a repository index needs but a raw
+// control file doesn't carry.
+func buildPackageStanza(path, relBinaryPath string) ([]byte, error) {
+	control, err := readDebControl(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sums, err := hashFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	filename := filepath.ToSlash(filepath.Join(relBinaryPath, filepath.Base(path)))
+
+	stanza := bytes.TrimRight(control, "\n")
+	stanza = append(stanza, []byte(fmt.Sprintf(
+		"\nFilename: %s\nSize: %d\nMD5sum: %s\nSHA1: %s\nSHA256: %s\n",
+		filename, info.Size(), sums.md5, sums.sha1, sums.sha256))...)
+
+	return stanza, nil
+}
+
+// readDebControl opens the .deb (an ar archive) at path, locates its
+// control.tar{,.gz} member, and returns the contents of the "control"
+// file within it.
+func readDebControl(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(arGlobalHeader))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return nil, errors.Wrapf(err, "reading ar header of '%s'", path)
+	}
+	if string(magic) != arGlobalHeader {
+		return nil, errors.Errorf("'%s' is not an ar archive", path)
+	}
+
+	for {
+		header := make([]byte, 60)
+		if _, err := io.ReadFull(f, header); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, errors.Wrapf(err, "reading ar member header in '%s'", path)
+		}
+
+		name := strings.TrimSuffix(strings.TrimRight(string(header[0:16]), " "), "/")
+		size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing ar member size for '%s' in '%s'", name, path)
+		}
+
+		if strings.HasPrefix(name, "control.tar") {
+			data := make([]byte, size)
+			if _, err := io.ReadFull(f, data); err != nil {
+				return nil, errors.Wrapf(err, "reading ar member '%s' in '%s'", name, path)
+			}
+
+			return extractControlFile(name, data)
+		}
+
+		skip := size
+		if size%2 != 0 {
+			skip++
+		}
+		if _, err := f.Seek(skip, io.SeekCurrent); err != nil {
+			return nil, errors.Wrapf(err, "seeking past ar member '%s' in '%s'", name, path)
+		}
+	}
+
+	return nil, errors.Errorf("no control.tar member found in '%s'", path)
+}
+
+// extractControlFile decompresses a control.tar{,.gz} member (named
+// per the ar entry name) and returns the "control" file contained
+// within.
+func extractControlFile(memberName string, data []byte) ([]byte, error) {
+	var r io.Reader = bytes.NewReader(data)
+
+	switch {
+	case strings.HasSuffix(memberName, ".tar"):
+		// already uncompressed
+	case strings.HasSuffix(memberName, ".tar.gz"):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "opening control.tar.gz")
+		}
+		defer gz.Close()
+		r = gz
+	default:
+		return nil, errors.Errorf("unsupported control archive format '%s'", memberName)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "reading control.tar")
+		}
+
+		if strings.TrimPrefix(hdr.Name, "./") == "control" {
+			return ioutil.ReadAll(tr)
+		}
+	}
+
+	return nil, errors.New("'control' file not found in control archive")
+}
+
+// fileChecksums holds the digests buildReleaseChecksumBlocks and
+// buildPackageStanza need for a single file.
+type fileChecksums struct {
+	md5    string
+	sha1   string
+	sha256 string
+	size   int64
+}
+
+// hashFile computes the MD5, SHA1, and SHA256 digests of the file at
+// path in a single pass.
+func hashFile(path string) (*fileChecksums, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	md5h, sha1h, sha256h := md5.New(), sha1.New(), sha256.New()
+	if _, err := io.Copy(io.MultiWriter(md5h, sha1h, sha256h), f); err != nil {
+		return nil, errors.Wrapf(err, "hashing '%s'", path)
+	}
+
+	return &fileChecksums{
+		md5:    hex.EncodeToString(md5h.Sum(nil)),
+		sha1:   hex.EncodeToString(sha1h.Sum(nil)),
+		sha256: hex.EncodeToString(sha256h.Sum(nil)),
+		size:   info.Size(),
+	}, nil
+}
+
+// packagesFilesIn returns the "Packages"/"Packages.gz" files under
+// componentDir, relative to componentDir, sorted for deterministic
+// Release output.
+func packagesFilesIn(componentDir string) ([]string, error) {
+	var rel []string
+	err := filepath.Walk(componentDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		name := filepath.Base(path)
+		if name != "Packages" && name != "Packages.gz" {
+			return nil
+		}
+
+		r, err := filepath.Rel(componentDir, path)
+		if err != nil {
+			return err
+		}
+
+		rel = append(rel, filepath.ToSlash(r))
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "walking '%s'", componentDir)
+	}
+
+	sort.Strings(rel)
+	return rel, nil
+}
+
+// buildReleaseChecksumBlocks renders the MD5Sum/SHA1/SHA256 sections
+// of a Release file for the given Packages* files, the content
+// apt-ftparchive's "release" subcommand used to supply.
+func buildReleaseChecksumBlocks(componentDir string, relFiles []string) (string, error) {
+	sums := make(map[string]*fileChecksums, len(relFiles))
+	for _, rel := range relFiles {
+		sum, err := hashFile(filepath.Join(componentDir, rel))
+		if err != nil {
+			return "", err
+		}
+		sums[rel] = sum
+	}
+
+	var buf bytes.Buffer
+	for _, block := range []struct {
+		header string
+		digest func(*fileChecksums) string
+	}{
+		{"MD5Sum:", func(s *fileChecksums) string { return s.md5 }},
+		{"SHA1:", func(s *fileChecksums) string { return s.sha1 }},
+		{"SHA256:", func(s *fileChecksums) string { return s.sha256 }},
+	} {
+		buf.WriteString(block.header)
+		buf.WriteString("\n")
+		for _, rel := range relFiles {
+			fmt.Fprintf(&buf, " %s %16d %s\n", block.digest(sums[rel]), sums[rel].size, rel)
+		}
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,165 @@
+package repobuilder
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func tarControlFile(t *testing.T, content string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "./control", Mode: 0644, Size: int64(len(content))}); err != nil {
+		t.Fatalf("writing tar header: %s", err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatalf("writing tar content: %s", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("closing tar writer: %s", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestExtractControlFile(t *testing.T) {
+	control := "Package: test\nVersion: 1.0\nArchitecture: amd64\n"
+	tarball := tarControlFile(t, control)
+
+	for _, test := range []struct {
+		memberName string
+		data       func(t *testing.T) []byte
+	}{
+		{memberName: "control.tar", data: func(t *testing.T) []byte { return tarball }},
+		{memberName: "control.tar.gz", data: func(t *testing.T) []byte {
+			var buf bytes.Buffer
+			w := gzip.NewWriter(&buf)
+			if _, err := w.Write(tarball); err != nil {
+				t.Fatalf("writing gzip content: %s", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("closing gzip writer: %s", err)
+			}
+			return buf.Bytes()
+		}},
+	} {
+		t.Run(test.memberName, func(t *testing.T) {
+			got, err := extractControlFile(test.memberName, test.data(t))
+			if err != nil {
+				t.Fatalf("extractControlFile returned error: %s", err)
+			}
+			if string(got) != control {
+				t.Fatalf("expected control %q, got %q", control, string(got))
+			}
+		})
+	}
+
+	t.Run("UnsupportedFormat", func(t *testing.T) {
+		if _, err := extractControlFile("control.tar.xz", []byte("irrelevant")); err == nil {
+			t.Fatalf("expected an error for an unsupported control archive format")
+		}
+	})
+}
+
+// writeArMember appends a single ar member (60-byte header plus
+// content, padded to an even length) to buf.
+func writeArMember(buf *bytes.Buffer, name string, data []byte) {
+	fmt.Fprintf(buf, "%-16s%-12s%-6s%-6s%-8s%-10d`\n", name, "0", "0", "0", "100644", len(data))
+	buf.Write(data)
+	if len(data)%2 != 0 {
+		buf.WriteByte('\n')
+	}
+}
+
+// buildFixtureDeb assembles a minimal valid .deb (an ar archive with a
+// debian-binary member and a control.tar.gz member) containing control.
+func buildFixtureDeb(t *testing.T, control string) []byte {
+	t.Helper()
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	if _, err := w.Write(tarControlFile(t, control)); err != nil {
+		t.Fatalf("writing control.tar.gz content: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("closing gzip writer: %s", err)
+	}
+
+	var ar bytes.Buffer
+	ar.WriteString(arGlobalHeader)
+	writeArMember(&ar, "debian-binary", []byte("2.0\n"))
+	writeArMember(&ar, "control.tar.gz", gz.Bytes())
+
+	return ar.Bytes()
+}
+
+func TestReadDebControl(t *testing.T) {
+	control := "Package: test\nVersion: 1.0\nArchitecture: amd64\n"
+
+	path := filepath.Join(t.TempDir(), "test.deb")
+	if err := ioutil.WriteFile(path, buildFixtureDeb(t, control), 0644); err != nil {
+		t.Fatalf("writing fixture .deb: %s", err)
+	}
+
+	got, err := readDebControl(path)
+	if err != nil {
+		t.Fatalf("readDebControl returned error: %s", err)
+	}
+	if string(got) != control {
+		t.Fatalf("expected control %q, got %q", control, string(got))
+	}
+}
+
+func TestRepoRootRelativePath(t *testing.T) {
+	// mirrors a real publish layout: <repo root>/<edition>/<version>/<distro>/<codename>/<component>
+	workingDir := filepath.Join("/data", "repos", "mongodb-org", "4.2", "debian", "wheezy", "main")
+
+	got := repoRootRelativePath(workingDir)
+	want := filepath.Join("mongodb-org", "4.2", "debian", "wheezy", "main")
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	// scanPackagesExternal runs dpkg-scanpackages from the directory
+	// above the last 5 segments of workingDir -- assert those 5
+	// segments are what repoRootRelativePath returns, so the native and
+	// external Filename: entries resolve against the same repo root.
+	dirParts := strings.Split(workingDir, string(filepath.Separator))
+	externalRelPath := filepath.Join(dirParts[len(dirParts)-5:]...)
+	if got != externalRelPath {
+		t.Fatalf("native relative path %q does not match external relative path %q", got, externalRelPath)
+	}
+}
+
+func TestBuildPackagesFileFilenameMatchesRepoRoot(t *testing.T) {
+	control := "Package: test\nVersion: 1.0\nArchitecture: amd64\n"
+
+	workingDir := filepath.Join("/data", "repos", "mongodb-org", "4.2", "debian", "wheezy", "main")
+	arch := "binary-amd64"
+
+	archDir := filepath.Join(t.TempDir(), arch)
+	if err := os.MkdirAll(archDir, 0755); err != nil {
+		t.Fatalf("creating fixture arch dir: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(archDir, "test.deb"), buildFixtureDeb(t, control), 0644); err != nil {
+		t.Fatalf("writing fixture .deb: %s", err)
+	}
+
+	content, err := buildPackagesFile(archDir, filepath.Join(repoRootRelativePath(workingDir), arch))
+	if err != nil {
+		t.Fatalf("buildPackagesFile returned error: %s", err)
+	}
+
+	wantFilename := "Filename: mongodb-org/4.2/debian/wheezy/main/binary-amd64/test.deb\n"
+	if !strings.Contains(string(content), wantFilename) {
+		t.Fatalf("expected output to contain %q, got:\n%s", wantFilename, content)
+	}
+}
@@ -0,0 +1,204 @@
+package repobuilder
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/grip"
+)
+
+// SnapshotFormat controls whether, and how, snapshotRepo archives a
+// built repo tree.
+type SnapshotFormat string
+
+const (
+	SnapshotFormatNone  SnapshotFormat = "none"
+	SnapshotFormatTar   SnapshotFormat = "tar"
+	SnapshotFormatTarGz SnapshotFormat = "tar.gz"
+)
+
+// snapshotRepo archives workingDir into a tar or tar.gz file, per
+// j.Conf.SnapshotFormat, written next to workingDir as
+// "<repo>-<version>-<arch>.<ext>" along with a sidecar ".sha256"
+// checksum file. The archive's path is recorded in j.Output so the
+// job report exposes it. A SnapshotFormatNone (or unset) config is a
+// no-op.
+//
+// TODO: there is no RPM equivalent of BuildDEBRepoJob in this tree
+// yet, so this is only wired up for debs. matchWalker,
+// writeTarArchive, and addTarEntry below don't reference
+// BuildDEBRepoJob at all; once an RPM build job exists it only needs
+// a thin method in the same shape as this one that calls them.
+func (j *BuildDEBRepoJob) snapshotRepo(workingDir string) error {
+	switch j.Conf.SnapshotFormat {
+	case "", SnapshotFormatNone:
+		return nil
+	case SnapshotFormatTar, SnapshotFormatTarGz:
+	default:
+		return errors.Errorf("unrecognized snapshot format '%s'", j.Conf.SnapshotFormat)
+	}
+
+	gzipped := j.Conf.SnapshotFormat == SnapshotFormatTarGz
+	ext := "tar"
+	if gzipped {
+		ext = "tar.gz"
+	}
+
+	name := fmt.Sprintf("%s-%s-%s.%s", filepath.Base(workingDir), j.Version, j.Arch, ext)
+	archivePath := filepath.Join(filepath.Dir(workingDir), name)
+
+	paths, err := matchWalker(workingDir, j.Conf.SnapshotExclude)
+	if err != nil {
+		return errors.Wrapf(err, "walking '%s'", workingDir)
+	}
+
+	checksum, err := writeTarArchive(archivePath, workingDir, paths, gzipped)
+	if err != nil {
+		return errors.Wrapf(err, "building snapshot archive for '%s'", workingDir)
+	}
+
+	checksumLine := fmt.Sprintf("%s  %s\n", checksum, filepath.Base(archivePath))
+	if err := ioutil.WriteFile(archivePath+".sha256", []byte(checksumLine), 0644); err != nil {
+		return errors.Wrap(err, "writing snapshot checksum")
+	}
+
+	j.mutex.Lock()
+	j.Output["snapshot-"+workingDir] = archivePath
+	j.mutex.Unlock()
+
+	grip.Noticeln("wrote repo snapshot to:", archivePath)
+	return nil
+}
+
+// matchWalker walks root and returns every entry's path relative to
+// root, skipping anything (and, for directories, their contents) that
+// matches one of the exclude glob patterns.
+func matchWalker(root string, exclude []string) ([]string, error) {
+	var paths []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		for _, pattern := range exclude {
+			if ok, _ := filepath.Match(pattern, rel); ok {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
+		paths = append(paths, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// writeTarArchive streams root's selected entries (regular files,
+// symlinks, and directories, with their modes and mtimes preserved)
+// into a tar file at archivePath, gzipping it when gzipped is set, and
+// returns the archive's SHA256 checksum.
+func writeTarArchive(archivePath, root string, relPaths []string, gzipped bool) (string, error) {
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer f.Close()
+
+	sha := sha256.New()
+	out := io.MultiWriter(f, sha)
+
+	var gz *gzip.Writer
+	tarOut := out
+	if gzipped {
+		gz = gzip.NewWriter(out)
+		tarOut = gz
+	}
+	tw := tar.NewWriter(tarOut)
+
+	for _, rel := range relPaths {
+		if err := addTarEntry(tw, root, rel); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+
+	return hex.EncodeToString(sha.Sum(nil)), nil
+}
+
+func addTarEntry(tw *tar.Writer, root, rel string) error {
+	full := filepath.Join(root, rel)
+
+	info, err := os.Lstat(full)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(full); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	hdr.Name = rel
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	src, err := os.Open(full)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer src.Close()
+
+	if _, err := io.Copy(tw, src); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
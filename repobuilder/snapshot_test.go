@@ -0,0 +1,143 @@
+package repobuilder
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestMatchWalker(t *testing.T) {
+	root := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(root, "keep"), 0755); err != nil {
+		t.Fatalf("creating 'keep' directory: %s", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "skip"), 0755); err != nil {
+		t.Fatalf("creating 'skip' directory: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "keep", "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("writing 'keep/a.txt': %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "skip", "b.txt"), []byte("b"), 0644); err != nil {
+		t.Fatalf("writing 'skip/b.txt': %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "c.tmp"), []byte("c"), 0644); err != nil {
+		t.Fatalf("writing 'c.tmp': %s", err)
+	}
+
+	paths, err := matchWalker(root, []string{"skip", "*.tmp"})
+	if err != nil {
+		t.Fatalf("matchWalker returned error: %s", err)
+	}
+
+	sort.Strings(paths)
+	want := []string{"keep", filepath.ToSlash(filepath.Join("keep", "a.txt"))}
+	if len(paths) != len(want) {
+		t.Fatalf("expected %v, got %v", want, paths)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, paths)
+		}
+	}
+}
+
+func TestWriteTarArchiveAndAddTarEntry(t *testing.T) {
+	root := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(root, "dir"), 0755); err != nil {
+		t.Fatalf("creating 'dir': %s", err)
+	}
+	content := []byte("hello snapshot")
+	if err := ioutil.WriteFile(filepath.Join(root, "dir", "file.txt"), content, 0644); err != nil {
+		t.Fatalf("writing 'dir/file.txt': %s", err)
+	}
+	if err := os.Symlink("file.txt", filepath.Join(root, "dir", "link.txt")); err != nil {
+		t.Fatalf("creating symlink: %s", err)
+	}
+
+	paths, err := matchWalker(root, nil)
+	if err != nil {
+		t.Fatalf("matchWalker returned error: %s", err)
+	}
+
+	for _, gzipped := range []bool{false, true} {
+		archivePath := filepath.Join(t.TempDir(), "snapshot.tar")
+		if gzipped {
+			archivePath += ".gz"
+		}
+
+		checksum, err := writeTarArchive(archivePath, root, paths, gzipped)
+		if err != nil {
+			t.Fatalf("writeTarArchive returned error: %s", err)
+		}
+
+		raw, err := ioutil.ReadFile(archivePath)
+		if err != nil {
+			t.Fatalf("reading archive: %s", err)
+		}
+		sum := sha256.Sum256(raw)
+		if hex.EncodeToString(sum[:]) != checksum {
+			t.Fatalf("expected returned checksum to match the archive's actual SHA256")
+		}
+
+		f, err := os.Open(archivePath)
+		if err != nil {
+			t.Fatalf("opening archive: %s", err)
+		}
+		defer f.Close()
+
+		var tarIn io.Reader = f
+		if gzipped {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatalf("opening gzip reader: %s", err)
+			}
+			defer gz.Close()
+			tarIn = gz
+		}
+
+		found := map[string]bool{}
+		var linkTarget string
+		tr := tar.NewReader(tarIn)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("reading tar entry: %s", err)
+			}
+			found[hdr.Name] = true
+
+			if hdr.Name == "dir/file.txt" {
+				data, err := ioutil.ReadAll(tr)
+				if err != nil {
+					t.Fatalf("reading tar entry content: %s", err)
+				}
+				if string(data) != string(content) {
+					t.Fatalf("expected content %q, got %q", content, data)
+				}
+			}
+			if hdr.Name == "dir/link.txt" {
+				linkTarget = hdr.Linkname
+			}
+		}
+
+		for _, name := range []string{"dir/", "dir/file.txt", "dir/link.txt"} {
+			if !found[name] {
+				t.Fatalf("expected archive to contain %q, got %v", name, found)
+			}
+		}
+		if linkTarget != "file.txt" {
+			t.Fatalf("expected symlink target 'file.txt', got %q", linkTarget)
+		}
+	}
+}
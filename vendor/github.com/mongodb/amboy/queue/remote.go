@@ -2,6 +2,7 @@ package queue
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/mongodb/amboy"
@@ -17,18 +18,85 @@ type Remote interface {
 	Driver() Driver
 }
 
+// RetryPolicy computes how long Next should wait before retrying a
+// job after a failed Lock or Get. NextDelay is called with the number
+// of consecutive failures observed for that job, starting at 1.
+type RetryPolicy interface {
+	NextDelay(attempt int) time.Duration
+}
+
+// exponentialBackoff is the default RetryPolicy: capped exponential
+// backoff starting at base and never exceeding max.
+type exponentialBackoff struct {
+	base time.Duration
+	max  time.Duration
+}
+
+// NewExponentialBackoff returns a RetryPolicy that doubles its delay
+// on every consecutive failure, starting at base and capping at max.
+func NewExponentialBackoff(base, max time.Duration) RetryPolicy {
+	return &exponentialBackoff{base: base, max: max}
+}
+
+func (b *exponentialBackoff) NextDelay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := b.base << uint(attempt-1)
+	if delay <= 0 || delay > b.max {
+		return b.max
+	}
+
+	return delay
+}
+
+const (
+	defaultNextRetryBase = 10 * time.Millisecond
+	defaultNextRetryMax  = time.Second
+)
+
 // RemoteUnordered are queues that use a Driver as backend for job
 // storage and processing and do not impose any additional ordering
 // beyond what's provided by the driver.
 type remoteUnordered struct {
 	*remoteBase
+	retryPolicy    RetryPolicy
+	nextTimeout    time.Duration
+	lockContention int64
+}
+
+// Option configures a Remote queue constructed by NewRemoteUnordered.
+type Option func(*remoteUnordered)
+
+// WithNextTimeout bounds how long Next blocks waiting for an unlocked
+// job before returning nil, even when the queue is non-empty but
+// every job is currently locked.
+func WithNextTimeout(d time.Duration) Option {
+	return func(q *remoteUnordered) {
+		q.nextTimeout = d
+	}
+}
+
+// WithRetryPolicy overrides the backoff Next applies between retries
+// after a failed Lock/Get, primarily so tests can swap in a
+// deterministic or instant policy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(q *remoteUnordered) {
+		q.retryPolicy = p
+	}
 }
 
 // NewRemoteUnordered returns a queue that has been initialized with a
 // local worker pool Runner instance of the specified size.
-func NewRemoteUnordered(size int) Remote {
+func NewRemoteUnordered(size int, opts ...Option) Remote {
 	q := &remoteUnordered{
-		remoteBase: newRemoteBase(),
+		remoteBase:  newRemoteBase(),
+		retryPolicy: NewExponentialBackoff(defaultNextRetryBase, defaultNextRetryMax),
+	}
+
+	for _, opt := range opts {
+		opt(q)
 	}
 
 	grip.CatchError(q.SetRunner(pool.NewLocalWorkers(size, q)))
@@ -38,32 +106,66 @@ func NewRemoteUnordered(size int) Remote {
 	return q
 }
 
+// LockContentionCount returns the number of Lock/Get rejections Next
+// has observed so far, giving operators visibility into contention
+// against the driver under a hot queue.
+func (q *remoteUnordered) LockContentionCount() int64 {
+	return atomic.LoadInt64(&q.lockContention)
+}
+
 // Next returns a Job from the queue. Returns a nil Job object if the
-// context is canceled. The operation is blocking until an
-// undispatched, unlocked job is available. This operation takes a job
-// lock.
+// context is canceled, or if a WithNextTimeout duration elapses
+// before an unlocked job becomes available. The operation is
+// otherwise blocking until an undispatched, unlocked job is
+// available. This operation takes a job lock.
+//
+// Repeated Lock/Get failures for the same job apply capped
+// exponential backoff before retrying, to avoid a thundering herd of
+// retries against a contested driver.
 func (q *remoteUnordered) Next(ctx context.Context) amboy.Job {
 	start := time.Now()
 	count := 0
+	failures := map[string]int{}
+
+	var timeoutCh <-chan time.Time
+	if q.nextTimeout > 0 {
+		timer := time.NewTimer(q.nextTimeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+
 	for {
 		count++
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-timeoutCh:
+			grip.Debugf("next timed out after %s waiting for an unlocked job", q.nextTimeout)
+			return nil
 		case job := <-q.channel:
+			id := job.ID()
+
 			err := q.driver.Lock(job)
 			if err != nil {
+				atomic.AddInt64(&q.lockContention, 1)
+				failures[id]++
 				grip.Warning(err)
+				q.waitForRetry(ctx, failures[id])
 				continue
 			}
 
-			job, err = q.driver.Get(job.ID())
+			job, err = q.driver.Get(id)
 			if err != nil {
+				atomic.AddInt64(&q.lockContention, 1)
+				failures[id]++
 				grip.CatchNotice(q.driver.Unlock(job))
 				grip.Warning(err)
+				q.waitForRetry(ctx, failures[id])
 				continue
 			}
 
+			delete(failures, id)
+
 			grip.Debugf("returning job from remote source, count = %d; duration = %s",
 				count, time.Since(start))
 
@@ -71,3 +173,20 @@ func (q *remoteUnordered) Next(ctx context.Context) amboy.Job {
 		}
 	}
 }
+
+// waitForRetry blocks for the delay q.retryPolicy assigns to attempt,
+// or until ctx is canceled, whichever comes first.
+func (q *remoteUnordered) waitForRetry(ctx context.Context, attempt int) {
+	delay := q.retryPolicy.NextDelay(attempt)
+	if delay <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,86 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoffNextDelay(t *testing.T) {
+	base := 10 * time.Millisecond
+	max := time.Second
+	b := NewExponentialBackoff(base, max)
+
+	for _, test := range []struct {
+		name    string
+		attempt int
+		want    time.Duration
+	}{
+		{name: "FirstAttempt", attempt: 1, want: base},
+		{name: "BelowOne", attempt: 0, want: base},
+		{name: "NegativeAttempt", attempt: -5, want: base},
+		{name: "Doubles", attempt: 2, want: 2 * base},
+		{name: "DoublesAgain", attempt: 3, want: 4 * base},
+		{name: "CapsAtMax", attempt: 10, want: max},
+		{name: "OverflowsToMax", attempt: 1000, want: max},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := b.NextDelay(test.attempt); got != test.want {
+				t.Fatalf("expected delay %s for attempt %d, got %s", test.want, test.attempt, got)
+			}
+		})
+	}
+}
+
+// constantRetryPolicy is a RetryPolicy that always returns the same
+// delay, regardless of attempt, for deterministic waitForRetry tests.
+type constantRetryPolicy struct {
+	delay time.Duration
+}
+
+func (p constantRetryPolicy) NextDelay(attempt int) time.Duration { return p.delay }
+
+func TestWaitForRetryRespectsContextCancellation(t *testing.T) {
+	q := &remoteUnordered{retryPolicy: constantRetryPolicy{delay: time.Hour}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		q.waitForRetry(ctx, 1)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("waitForRetry did not return promptly after context cancellation")
+	}
+}
+
+func TestWaitForRetryReturnsImmediatelyForNonPositiveDelay(t *testing.T) {
+	q := &remoteUnordered{retryPolicy: constantRetryPolicy{delay: 0}}
+
+	done := make(chan struct{})
+	go func() {
+		q.waitForRetry(context.Background(), 1)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("waitForRetry did not return immediately for a zero delay")
+	}
+}
+
+func TestWaitForRetryWaitsOutTheDelay(t *testing.T) {
+	q := &remoteUnordered{retryPolicy: constantRetryPolicy{delay: 20 * time.Millisecond}}
+
+	start := time.Now()
+	q.waitForRetry(context.Background(), 1)
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("expected waitForRetry to block for at least 20ms, returned after %s", elapsed)
+	}
+}
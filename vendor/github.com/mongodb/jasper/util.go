@@ -48,3 +48,164 @@ func WriteFile(reader io.Reader, path string) error {
 
 	return nil
 }
+
+// CopyOptions describes how CopyFile and CopyTree should copy a file
+// or directory tree.
+type CopyOptions struct {
+	// UID and GID, when non-nil, chown the copied file (or, for
+	// CopyTree, every file and directory copied) to the given owner
+	// once the copy completes.
+	UID, GID *int
+
+	// PreserveXattr copies extended attributes from the source to
+	// the destination. Only implemented on Linux; on other
+	// platforms it is a no-op.
+	PreserveXattr bool
+
+	// FollowSymlinks controls how symlinks are handled. By default
+	// (false) a symlink is recreated as a symlink pointing at the
+	// same target; when true, the file it points to is copied
+	// instead.
+	FollowSymlinks bool
+}
+
+// CopyFile copies the file (or symlink) at src to dst, preserving the
+// source's mode and modification time. It chowns and copies extended
+// attributes according to opts. A symlink recreated as a link (the
+// default) only has its ownership set from opts; its mtime and
+// extended attributes are not copied.
+func CopyFile(src, dst string, opts CopyOptions) error {
+	if err := MakeEnclosingDirectories(filepath.Dir(dst)); err != nil {
+		return errors.Wrap(err, "problem making enclosing directories")
+	}
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		return errors.Wrapf(err, "problem statting '%s'", src)
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		if !opts.FollowSymlinks {
+			return copySymlink(src, dst, info, opts)
+		}
+
+		// os.Open below dereferences the link, so info must describe
+		// the link's target rather than the link itself.
+		if info, err = os.Stat(src); err != nil {
+			return errors.Wrapf(err, "problem statting '%s'", src)
+		}
+	}
+
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "problem opening '%s'", src)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return errors.Wrapf(err, "problem creating '%s'", dst)
+	}
+	defer dstFile.Close()
+
+	if _, err = io.Copy(dstFile, srcFile); err != nil {
+		return errors.Wrapf(err, "problem copying '%s' to '%s'", src, dst)
+	}
+
+	if err = dstFile.Close(); err != nil {
+		return errors.Wrapf(err, "problem closing '%s'", dst)
+	}
+
+	return finishCopy(src, dst, info, opts)
+}
+
+func copySymlink(src, dst string, info os.FileInfo, opts CopyOptions) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return errors.Wrapf(err, "problem reading link '%s'", src)
+	}
+
+	if _, err = os.Lstat(dst); err == nil {
+		if err = os.Remove(dst); err != nil {
+			return errors.Wrapf(err, "problem removing existing '%s'", dst)
+		}
+	}
+
+	if err = os.Symlink(target, dst); err != nil {
+		return errors.Wrapf(err, "problem linking '%s' to '%s'", dst, target)
+	}
+
+	if opts.UID != nil && opts.GID != nil {
+		if err = os.Lchown(dst, *opts.UID, *opts.GID); err != nil {
+			return errors.Wrapf(err, "problem chowning '%s'", dst)
+		}
+	}
+
+	return nil
+}
+
+func finishCopy(src, dst string, info os.FileInfo, opts CopyOptions) error {
+	if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+		return errors.Wrapf(err, "problem chmodding '%s'", dst)
+	}
+
+	modTime := info.ModTime()
+	if err := os.Chtimes(dst, modTime, modTime); err != nil {
+		return errors.Wrapf(err, "problem setting mtime on '%s'", dst)
+	}
+
+	if opts.UID != nil && opts.GID != nil {
+		if err := os.Chown(dst, *opts.UID, *opts.GID); err != nil {
+			return errors.Wrapf(err, "problem chowning '%s'", dst)
+		}
+	}
+
+	if opts.PreserveXattr {
+		if err := copyXattrs(src, dst); err != nil {
+			return errors.Wrapf(err, "problem copying extended attributes from '%s' to '%s'", src, dst)
+		}
+	}
+
+	return nil
+}
+
+// CopyTree recursively copies the directory tree rooted at src to
+// dst, applying the same semantics as CopyFile to every file,
+// directory, and symlink it encounters.
+func CopyTree(src, dst string, opts CopyOptions) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return errors.Wrapf(err, "problem statting '%s'", src)
+	}
+	if err = os.MkdirAll(dst, srcInfo.Mode().Perm()); err != nil {
+		return errors.Wrapf(err, "problem creating directory '%s'", dst)
+	}
+	if err = finishCopy(src, dst, srcInfo, opts); err != nil {
+		return err
+	}
+
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return errors.Wrapf(err, "problem resolving '%s' relative to '%s'", path, src)
+		}
+
+		if rel == "." {
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			if err = os.MkdirAll(target, info.Mode().Perm()); err != nil {
+				return errors.Wrapf(err, "problem creating directory '%s'", target)
+			}
+			return finishCopy(path, target, info, opts)
+		}
+
+		return CopyFile(path, target, opts)
+	})
+}
@@ -0,0 +1,64 @@
+// +build linux
+
+package jasper
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// copyXattrs copies the extended attributes of src onto dst.
+func copyXattrs(src, dst string) error {
+	size, err := syscall.Listxattr(src, nil)
+	if err != nil {
+		if err == syscall.ENOTSUP {
+			return nil
+		}
+		return errors.Wrapf(err, "problem listing extended attributes of '%s'", src)
+	}
+	if size == 0 {
+		return nil
+	}
+
+	names := make([]byte, size)
+	if _, err = syscall.Listxattr(src, names); err != nil {
+		return errors.Wrapf(err, "problem listing extended attributes of '%s'", src)
+	}
+
+	for _, name := range splitXattrNames(names) {
+		valSize, err := syscall.Getxattr(src, name, nil)
+		if err != nil {
+			return errors.Wrapf(err, "problem reading extended attribute '%s' from '%s'", name, src)
+		}
+
+		val := make([]byte, valSize)
+		if _, err = syscall.Getxattr(src, name, val); err != nil {
+			return errors.Wrapf(err, "problem reading extended attribute '%s' from '%s'", name, src)
+		}
+
+		if err = syscall.Setxattr(dst, name, val, 0); err != nil {
+			return errors.Wrapf(err, "problem setting extended attribute '%s' on '%s'", name, dst)
+		}
+	}
+
+	return nil
+}
+
+// splitXattrNames splits the NUL-separated buffer returned by
+// Listxattr into individual attribute names.
+func splitXattrNames(buf []byte) []string {
+	var names []string
+
+	start := 0
+	for i, b := range buf {
+		if b == 0 {
+			if i > start {
+				names = append(names, string(buf[start:i]))
+			}
+			start = i + 1
+		}
+	}
+
+	return names
+}
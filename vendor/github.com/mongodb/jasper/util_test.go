@@ -0,0 +1,122 @@
+package jasper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCopyFileFollowsSymlinkMetadata(t *testing.T) {
+	dir := t.TempDir()
+
+	target := filepath.Join(dir, "target")
+	if err := ioutil.WriteFile(target, []byte("hello"), 0600); err != nil {
+		t.Fatalf("writing target file: %s", err)
+	}
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(target, mtime, mtime); err != nil {
+		t.Fatalf("setting target mtime: %s", err)
+	}
+
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("creating symlink: %s", err)
+	}
+
+	dst := filepath.Join(dir, "copy")
+	if err := CopyFile(link, dst, CopyOptions{FollowSymlinks: true}); err != nil {
+		t.Fatalf("CopyFile returned error: %s", err)
+	}
+
+	info, err := os.Lstat(dst)
+	if err != nil {
+		t.Fatalf("statting copy: %s", err)
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		t.Fatalf("expected copy to be a regular file, got a symlink")
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("expected mode 0600, got %o", perm)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Fatalf("expected mtime %s, got %s", mtime, info.ModTime())
+	}
+}
+
+func TestCopyFilePreservesSymlinkByDefault(t *testing.T) {
+	dir := t.TempDir()
+
+	target := filepath.Join(dir, "target")
+	if err := ioutil.WriteFile(target, []byte("hello"), 0644); err != nil {
+		t.Fatalf("writing target file: %s", err)
+	}
+
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("creating symlink: %s", err)
+	}
+
+	dst := filepath.Join(dir, "copy")
+	if err := CopyFile(link, dst, CopyOptions{}); err != nil {
+		t.Fatalf("CopyFile returned error: %s", err)
+	}
+
+	info, err := os.Lstat(dst)
+	if err != nil {
+		t.Fatalf("statting copy: %s", err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected copy to be a symlink")
+	}
+
+	resolved, err := os.Readlink(dst)
+	if err != nil {
+		t.Fatalf("reading copied link: %s", err)
+	}
+	if resolved != target {
+		t.Fatalf("expected link target %q, got %q", target, resolved)
+	}
+}
+
+func TestCopyTreeCreatesEmptyDestination(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	if err := CopyTree(src, dst, CopyOptions{}); err != nil {
+		t.Fatalf("CopyTree returned error: %s", err)
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatalf("expected destination directory to exist: %s", err)
+	}
+	if !info.IsDir() {
+		t.Fatalf("expected destination to be a directory")
+	}
+}
+
+func TestCopyTreeCopiesNestedFiles(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "nested"), 0755); err != nil {
+		t.Fatalf("creating nested source directory: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "nested", "file.txt"), []byte("content"), 0640); err != nil {
+		t.Fatalf("writing nested source file: %s", err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := CopyTree(src, dst, CopyOptions{}); err != nil {
+		t.Fatalf("CopyTree returned error: %s", err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dst, "nested", "file.txt"))
+	if err != nil {
+		t.Fatalf("reading copied file: %s", err)
+	}
+	if string(content) != "content" {
+		t.Fatalf("expected copied content %q, got %q", "content", string(content))
+	}
+}